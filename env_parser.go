@@ -8,7 +8,19 @@ import (
 	"strings"
 )
 
-func EnvParser(r io.Reader, set func(name, value string) error) error {
+// EnvParser is a ConfigFileParser which parses a simple line-oriented
+// `key = value` document. An `include <path>` or `@include <path>` line
+// pulls in a sibling file, resolved relative to the file currently being
+// read; includes are processed after this document's own key/value lines,
+// regardless of where the include line falls, so this document's own values
+// win over an included file's. See ParseState.Include for cycle detection
+// and search path rules.
+func EnvParser(r io.Reader, set func(name, value string) error, ps *ParseState) error {
+	var (
+		pairs    [][2]string
+		includes []string
+	)
+
 	s := bufio.NewScanner(r)
 	for s.Scan() {
 		line := strings.TrimSpace(s.Text())
@@ -20,6 +32,11 @@ func EnvParser(r io.Reader, set func(name, value string) error) error {
 			continue // skip comments
 		}
 
+		if path, ok := includeDirective(line); ok {
+			includes = append(includes, path)
+			continue
+		}
+
 		index := strings.IndexRune(line, '=')
 		if index < 0 {
 			return fmt.Errorf("invalid line: %s", line)
@@ -46,9 +63,49 @@ func EnvParser(r io.Reader, set func(name, value string) error) error {
 			value = unquoted
 		}
 
-		if err := set(name, value); err != nil {
+		pairs = append(pairs, [2]string{name, value})
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	touched := map[string]bool{}
+	for _, pair := range pairs {
+		touched[pair[0]] = true
+		if err := set(pair[0], pair[1]); err != nil {
 			return err
 		}
 	}
+
+	for _, path := range includes {
+		if ps == nil {
+			return fmt.Errorf("include %q: EnvParser invoked without a ParseState, cannot resolve relative paths", path)
+		}
+		if err := ps.Include(path, EnvParser, func(name, value string) error {
+			if touched[name] {
+				return nil
+			}
+			return set(name, value)
+		}); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// includeDirective reports whether line is an `include <path>` or
+// `@include <path>` directive, and if so, the (possibly quoted) path.
+func includeDirective(line string) (path string, ok bool) {
+	for _, prefix := range []string{"@include ", "include "} {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		path = strings.TrimSpace(strings.TrimPrefix(line, prefix))
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		return path, true
+	}
+	return "", false
+}
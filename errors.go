@@ -0,0 +1,24 @@
+package ff
+
+import "strings"
+
+// ParseError aggregates every problem Parse found while validating flags --
+// via WithValidate, WithRequired, or WithStrictConfig -- into a single
+// error, rather than returning only the first one.
+type ParseError struct {
+	Errs []error
+}
+
+func (e *ParseError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap allows errors.Is and errors.As to traverse every error ParseError
+// aggregates.
+func (e *ParseError) Unwrap() []error {
+	return e.Errs
+}
@@ -0,0 +1,184 @@
+// Package ffstruct registers a flag.FlagSet's flags directly from a struct's
+// fields, so callers can skip the boilerplate of calling fs.StringVar (and
+// friends) once per option while still parsing through ff.Parse.
+package ffstruct
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterStruct reflects over v, which must be a pointer to a struct, and
+// registers one flag per exported field on fs. Fields are tagged with
+// `ff:"name=...,usage=...,default=...,env=..."`; name defaults to the
+// lowercased field name if omitted. Nested structs, and pointers to structs,
+// produce dotted flag names -- a ListenAddr field tagged
+// `ff:"name=listen-addr"` inside a Server field tagged `ff:"name=server"`
+// becomes "server.listen-addr" -- compatible with
+// ff's env var mapping, which already treats '.', '-', and '/' as
+// equivalent. After fs.Parse (or ff.Parse) returns, the struct's fields hold
+// the resolved values.
+func RegisterStruct(fs *flag.FlagSet, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ffstruct: RegisterStruct requires a non-nil pointer to a struct, got %T", v)
+	}
+	return registerFields(fs, "", rv.Elem())
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func registerFields(fs *flag.FlagSet, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		tag, err := parseTag(field.Tag.Get("ff"))
+		if err != nil {
+			return fmt.Errorf("ffstruct: field %s: %w", field.Name, err)
+		}
+
+		name := tag["name"]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			if err := registerFields(fs, name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		def := tag["default"]
+		if env := tag["env"]; env != "" {
+			if value, ok := os.LookupEnv(env); ok {
+				def = value
+			}
+		}
+
+		if err := registerField(fs, name, tag["usage"], def, fv); err != nil {
+			return fmt.Errorf("ffstruct: field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseTag splits a `ff:"name=...,usage=...,..."` struct tag into its
+// key/value pairs.
+func parseTag(tag string) (map[string]string, error) {
+	out := map[string]string{}
+	if tag == "" {
+		return out, nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid ff tag segment %q", part)
+		}
+		out[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return out, nil
+}
+
+// registerField binds a single leaf field to fs, using the field's kind to
+// pick the matching fs.XxxVar registration function.
+func registerField(fs *flag.FlagSet, name, usage, def string, fv reflect.Value) error {
+	switch {
+	case fv.Type() == durationType:
+		d := time.Duration(0)
+		if def != "" {
+			parsed, err := time.ParseDuration(def)
+			if err != nil {
+				return fmt.Errorf("default %q: %w", def, err)
+			}
+			d = parsed
+		}
+		fs.DurationVar(fv.Addr().Interface().(*time.Duration), name, d, usage)
+
+	case fv.Kind() == reflect.String:
+		fs.StringVar(fv.Addr().Interface().(*string), name, def, usage)
+
+	case fv.Kind() == reflect.Bool:
+		b := false
+		if def != "" {
+			parsed, err := strconv.ParseBool(def)
+			if err != nil {
+				return fmt.Errorf("default %q: %w", def, err)
+			}
+			b = parsed
+		}
+		fs.BoolVar(fv.Addr().Interface().(*bool), name, b, usage)
+
+	case fv.Kind() == reflect.Int:
+		n := 0
+		if def != "" {
+			parsed, err := strconv.Atoi(def)
+			if err != nil {
+				return fmt.Errorf("default %q: %w", def, err)
+			}
+			n = parsed
+		}
+		fs.IntVar(fv.Addr().Interface().(*int), name, n, usage)
+
+	case fv.Kind() == reflect.Int64:
+		n := int64(0)
+		if def != "" {
+			parsed, err := strconv.ParseInt(def, 10, 64)
+			if err != nil {
+				return fmt.Errorf("default %q: %w", def, err)
+			}
+			n = parsed
+		}
+		fs.Int64Var(fv.Addr().Interface().(*int64), name, n, usage)
+
+	case fv.Kind() == reflect.Uint:
+		n := uint(0)
+		if def != "" {
+			parsed, err := strconv.ParseUint(def, 10, 64)
+			if err != nil {
+				return fmt.Errorf("default %q: %w", def, err)
+			}
+			n = uint(parsed)
+		}
+		fs.UintVar(fv.Addr().Interface().(*uint), name, n, usage)
+
+	case fv.Kind() == reflect.Float64:
+		f := float64(0)
+		if def != "" {
+			parsed, err := strconv.ParseFloat(def, 64)
+			if err != nil {
+				return fmt.Errorf("default %q: %w", def, err)
+			}
+			f = parsed
+		}
+		fs.Float64Var(fv.Addr().Interface().(*float64), name, f, usage)
+
+	default:
+		return fmt.Errorf("unsupported field kind %s for flag %q", fv.Kind(), name)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package ffstruct
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestRegisterStruct(t *testing.T) {
+	type Server struct {
+		ListenAddr string        `ff:"name=listen-addr,usage=address to listen on,default=:8080"`
+		Timeout    time.Duration `ff:"default=5s"`
+	}
+	type Config struct {
+		Verbose bool   `ff:"default=true"`
+		Count   int    `ff:"default=3"`
+		Server  Server `ff:"name=server"`
+	}
+
+	var cfg Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterStruct(fs, &cfg); err != nil {
+		t.Fatalf("RegisterStruct: %v", err)
+	}
+
+	if err := fs.Parse([]string{
+		"--server.listen-addr=:9090",
+		"--verbose=false",
+	}); err != nil {
+		t.Fatalf("fs.Parse: %v", err)
+	}
+
+	if cfg.Server.ListenAddr != ":9090" {
+		t.Errorf("Server.ListenAddr = %q, want %q", cfg.Server.ListenAddr, ":9090")
+	}
+	if cfg.Server.Timeout != 5*time.Second {
+		t.Errorf("Server.Timeout = %v, want %v", cfg.Server.Timeout, 5*time.Second)
+	}
+	if cfg.Verbose {
+		t.Error("Verbose = true, want false")
+	}
+	if cfg.Count != 3 {
+		t.Errorf("Count = %d, want 3", cfg.Count)
+	}
+}
+
+func TestRegisterStruct_UnsupportedFieldKind(t *testing.T) {
+	type Config struct {
+		Tags []string
+	}
+
+	var cfg Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterStruct(fs, &cfg); err == nil {
+		t.Fatal("RegisterStruct: want error for unsupported field kind, got nil")
+	}
+}
+
+func TestRegisterStruct_BadTagSyntax(t *testing.T) {
+	type Config struct {
+		Name string `ff:"not-a-key-value-pair"`
+	}
+
+	var cfg Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterStruct(fs, &cfg); err == nil {
+		t.Fatal("RegisterStruct: want error for malformed ff tag, got nil")
+	}
+}
+
+func TestRegisterStruct_RequiresPointerToStruct(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterStruct(fs, struct{}{}); err == nil {
+		t.Fatal("RegisterStruct: want error for non-pointer argument, got nil")
+	}
+}
@@ -0,0 +1,157 @@
+package ff
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// applyWithIncludes flattens doc's own keys through set, tracking which flag
+// names it touched, and only then processes each path in includes, routing
+// included values through set -- except for names doc's own keys already
+// touched. This makes the including file's own values win over an included
+// file's, matching every comparable include/import mechanism (an including
+// file is expected to override the shared defaults it pulls in, not the
+// other way around).
+func applyWithIncludes(doc map[string]interface{}, includes []string, ps *ParseState, parser ConfigFileParser, set func(name, value string) error) error {
+	touched := map[string]bool{}
+	if err := flattenConfig(doc, func(name, value string) error {
+		touched[name] = true
+		return set(name, value)
+	}); err != nil {
+		return err
+	}
+
+	for _, path := range includes {
+		if ps == nil {
+			return fmt.Errorf("include %q: used without a ParseState, cannot resolve relative paths", path)
+		}
+		if err := ps.Include(path, parser, func(name, value string) error {
+			if touched[name] {
+				return nil
+			}
+			return set(name, value)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// popIncludes removes and returns the "include" or "@include" key from a
+// decoded config document, if present, as a list of paths. The key's value
+// may be a single string or a list of strings.
+func popIncludes(doc map[string]interface{}) ([]string, error) {
+	var raw interface{}
+	for _, key := range []string{"@include", "include"} {
+		v, ok := doc[key]
+		if !ok {
+			continue
+		}
+		raw = v
+		delete(doc, key)
+		break
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		paths := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("include directive: %v is not a string", e)
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("include directive: unsupported value %v", v)
+	}
+}
+
+// flattenConfig walks a decoded config document -- as produced by
+// encoding/json, yaml.v3, or BurntSushi/toml, all of which decode objects to
+// map[string]interface{} -- and calls set once per leaf value, joining nested
+// keys with a dot so the result matches how flagNameToEnvVar already treats
+// '.', '-', and '/' as equivalent separators. Arrays call set once per
+// element, so repeatable flags can be populated from a config file list.
+func flattenConfig(doc map[string]interface{}, set func(name, value string) error) error {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := flattenValue(k, doc[k], set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flattenValue(name string, v interface{}, set func(name, value string) error) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := flattenValue(name+"."+k, v[k], set); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []interface{}:
+		for _, e := range v {
+			if err := flattenValue(name, e, set); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		s, err := stringifyLeaf(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return set(name, s)
+	}
+}
+
+// stringifyLeaf renders a decoded scalar in its canonical Go form, so that
+// the result is accepted by flag.Value.Set. Whole-number float64s -- which
+// is how every JSON number decodes -- are rendered with FormatInt rather
+// than FormatFloat's 'g' verb, so a large value like 1e+11 doesn't come out
+// in exponential notation that ParseInt/Atoi reject.
+func stringifyLeaf(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v <= math.MaxInt64 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported config value type %T", v)
+	}
+}
@@ -0,0 +1,41 @@
+package ff
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONParser_IncludeLocalWins(t *testing.T) {
+	dir := t.TempDir()
+
+	sub := filepath.Join(dir, "sub.json")
+	if err := os.WriteFile(sub, []byte(`{"a":"from-sub","b":"only-in-sub"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	main := filepath.Join(dir, "main.json")
+	if err := os.WriteFile(main, []byte(`{"include":"sub.json","a":"from-main"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var a, b string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&a, "a", "", "")
+	fs.StringVar(&b, "b", "", "")
+
+	if err := Parse(fs, nil,
+		WithConfigFile(main),
+		WithConfigFileParser(JSONParser),
+	); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if a != "from-main" {
+		t.Errorf("a = %q, want %q (the including file's own value should win)", a, "from-main")
+	}
+	if b != "only-in-sub" {
+		t.Errorf("b = %q, want %q (values only present in the included file should still apply)", b, "only-in-sub")
+	}
+}
@@ -0,0 +1,30 @@
+package ff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONParser is a ConfigFileParser which parses a JSON document, flattening
+// nested objects into dotted flag names -- e.g. {"server":{"listen-addr":
+// ":8080"}} becomes the flag name "server.listen-addr" -- and calling set
+// once per leaf. Arrays call set once per element, so a JSON array can
+// populate a repeatable flag. A top-level "include" or "@include" key,
+// holding a string or list of strings, pulls in sibling files after this
+// document's own keys are applied, so this document's values win over an
+// included file's; see ParseState.Include for cycle detection and search
+// path rules.
+func JSONParser(r io.Reader, set func(name, value string) error, ps *ParseState) error {
+	var doc map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("error parsing JSON config: %w", err)
+	}
+
+	includes, err := popIncludes(doc)
+	if err != nil {
+		return fmt.Errorf("error parsing JSON config: %w", err)
+	}
+
+	return applyWithIncludes(doc, includes, ps, JSONParser, set)
+}
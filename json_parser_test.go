@@ -0,0 +1,78 @@
+package ff
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stringSliceValue is a minimal repeatable flag.Value, appending each Set
+// call instead of overwriting, the way a []string flag is normally
+// implemented by callers of this package.
+type stringSliceValue []string
+
+func (s *stringSliceValue) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceValue) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func TestJSONParser_LargeIntNotExponential(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"max-bytes":10000000000}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var maxBytes int64
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int64Var(&maxBytes, "max-bytes", 0, "")
+
+	if err := Parse(fs, nil,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+	); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if want := int64(10000000000); maxBytes != want {
+		t.Errorf("max-bytes = %d, want %d", maxBytes, want)
+	}
+}
+
+func TestJSONParser_Array(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"tag":["a","b","c"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var tags stringSliceValue
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&tags, "tag", "repeatable tag")
+
+	if err := Parse(fs, nil,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+	); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []string{"a", "b", "c"}
+	if len(tags) != len(want) {
+		t.Fatalf("tag = %v, want %v", []string(tags), want)
+	}
+	for i, v := range want {
+		if tags[i] != v {
+			t.Errorf("tag[%d] = %q, want %q", i, tags[i], v)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package ff
 
+import (
+	"flag"
+	"fmt"
+)
+
 // Context contains private fields used during parsing.
 type Context struct {
 	configFileVia              *string
@@ -10,6 +15,11 @@ type Context struct {
 	envVarNoPrefix             bool
 	envVarSplit                string
 	ignoreUndefinedConfigFlags bool
+	sources                    []sourceLayer
+	configFileIncludeDirs      []string
+	maxIncludeDepth            int
+	validators                 []func(f *flag.Flag, source string) error
+	strictConfig               bool
 }
 
 // Option controls some aspect of Parse behavior.
@@ -117,3 +127,79 @@ func WithIgnoreUndefinedConfigFlags() Option {
 		c.ignoreUndefinedConfigFlags = true
 	}
 }
+
+// WithConfigFileIncludeDirs tells Parse's config file layer where to look for
+// files named by an include/@include directive that aren't found relative to
+// the file doing the including. Directories are searched in the order given,
+// after the including file's own directory.
+func WithConfigFileIncludeDirs(dirs ...string) Option {
+	return func(c *Context) {
+		c.configFileIncludeDirs = dirs
+	}
+}
+
+// WithMaxIncludeDepth bounds how deeply include/@include directives may
+// nest, to guard against runaway or mutually-recursive config files. The
+// default, zero, means unbounded; cycles are still detected and rejected
+// regardless of this setting.
+func WithMaxIncludeDepth(n int) Option {
+	return func(c *Context) {
+		c.maxIncludeDepth = n
+	}
+}
+
+// WithValidate registers a validation function that Parse calls once per
+// flag in the flag set, after every layer -- commandline, env, config file,
+// and any Source registered with WithSource -- has had a chance to set it.
+// source is the Origin that produced the flag's effective value, or
+// OriginDefault if nothing did. Parse aggregates every non-nil error
+// returned, across every flag and every registered validator, into a single
+// *ParseError instead of stopping at the first one.
+func WithValidate(validate func(f *flag.Flag, source string) error) Option {
+	return func(c *Context) {
+		c.validators = append(c.validators, validate)
+	}
+}
+
+// WithRequired tells Parse that each named flag must have been given an
+// explicit value -- by the commandline, an env var, a config file, or a
+// registered Source -- rather than be left at its default. It's implemented
+// as a WithValidate function, and participates in the same *ParseError
+// aggregation.
+func WithRequired(names ...string) Option {
+	required := make(map[string]bool, len(names))
+	for _, name := range names {
+		required[name] = true
+	}
+	return WithValidate(func(f *flag.Flag, source string) error {
+		if required[f.Name] && source == OriginDefault {
+			return fmt.Errorf("required flag %q was not set", f.Name)
+		}
+		return nil
+	})
+}
+
+// WithStrictConfig tells Parse's config file layer to aggregate two kinds of
+// problem into a single *ParseError instead of failing at the first one,
+// similar to Docker's findConfigurationConflicts: every key in the config
+// file that isn't defined in the flag set is reported (instead of just the
+// first), and so is every key that appears in the config file but was
+// already set on the commandline. Other config file errors, such as an
+// ambiguous key or a value the flag rejects, still end the layer at once.
+func WithStrictConfig(strict bool) Option {
+	return func(c *Context) {
+		c.strictConfig = strict
+	}
+}
+
+// WithSource registers an additional layer of flag values with Parse. The
+// source is consulted at the given priority, interleaved with the built-in
+// environment variable (PriorityEnvVar) and config file (PriorityConfigFile)
+// layers: a higher priority runs -- and therefore wins, absent a value from
+// the commandline -- before a lower one. Registering no sources preserves
+// the current default commandline/env/config-file behavior.
+func WithSource(s Source, priority int) Option {
+	return func(c *Context) {
+		c.sources = append(c.sources, sourceLayer{source: s, priority: priority})
+	}
+}
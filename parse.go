@@ -5,19 +5,164 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
-// ConfigFileParser interprets the config file represented by the reader
-// and calls the set function for each parsed flag pair.
-type ConfigFileParser func(r io.Reader, set func(name, value string) error) error
+// ConfigFileParser interprets the config file represented by the reader and
+// calls the set function for each parsed flag pair. Parsers that honor
+// include/@include directives (EnvParser, JSONParser, YAMLParser, TOMLParser)
+// use ps to resolve the included path and to detect include cycles; ps is
+// nil when the parser is invoked directly rather than via Parse, in which
+// case an include directive is an error.
+type ConfigFileParser func(r io.Reader, set func(name, value string) error, ps *ParseState) error
+
+// ParseState is threaded through a ConfigFileParser as one config file
+// includes another, so parsers can resolve include paths relative to the
+// file currently being read and so Parse can detect include cycles and
+// enforce WithMaxIncludeDepth.
+type ParseState struct {
+	// Dir is the directory of the file currently being parsed; relative
+	// include paths are resolved against it first.
+	Dir string
+
+	// IncludeDirs are the additional search paths from
+	// WithConfigFileIncludeDirs, tried in order if an include path isn't
+	// found relative to Dir.
+	IncludeDirs []string
+
+	stack        []string
+	touchedFiles map[string]bool
+	maxDepth     int
+}
+
+// Include resolves path relative to ps.Dir and then ps.IncludeDirs, and
+// parses the result with parser, routing its flag pairs through set. It
+// returns an error if path is already on the current include stack (a
+// cycle) or if WithMaxIncludeDepth has been exceeded. Including a file that
+// has already been loaded elsewhere in this Parse call is a silent no-op.
+func (ps *ParseState) Include(path string, parser ConfigFileParser, set func(name, value string) error) error {
+	resolved, err := ps.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range ps.stack {
+		if p != resolved {
+			continue
+		}
+		return fmt.Errorf("include cycle detected: %s", strings.Join(append(ps.stack, resolved), " -> "))
+	}
+
+	if ps.maxDepth > 0 && len(ps.stack) > ps.maxDepth {
+		return fmt.Errorf("include %q: max include depth %d exceeded", path, ps.maxDepth)
+	}
+
+	if ps.touchedFiles[resolved] {
+		return nil
+	}
+	ps.touchedFiles[resolved] = true
+
+	f, err := os.Open(resolved)
+	if err != nil {
+		return fmt.Errorf("error including %q: %w", path, err)
+	}
+	defer f.Close()
+
+	child := &ParseState{
+		Dir:          filepath.Dir(resolved),
+		IncludeDirs:  ps.IncludeDirs,
+		stack:        append(append([]string{}, ps.stack...), resolved),
+		touchedFiles: ps.touchedFiles,
+		maxDepth:     ps.maxDepth,
+	}
+	return parser(f, set, child)
+}
+
+func (ps *ParseState) resolve(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+
+	candidates := make([]string, 0, 1+len(ps.IncludeDirs))
+	candidates = append(candidates, filepath.Join(ps.Dir, path))
+	for _, dir := range ps.IncludeDirs {
+		candidates = append(candidates, filepath.Join(dir, path))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return filepath.Clean(candidate), nil
+		}
+	}
+
+	return "", fmt.Errorf("include %q: not found (searched %s)", path, strings.Join(candidates, ", "))
+}
 
-type ConfigFileLookup func(fs *flag.FlagSet, name string) *flag.Flag
+// Built-in priorities for the environment variable and config file layers,
+// for use with WithSource. A registered Source runs before a built-in layer
+// of lower priority, and after one of higher priority; commandline flags are
+// always the highest-priority layer and always run first.
+const (
+	PriorityConfigFile = 10
+	PriorityEnvVar     = 50
+)
+
+// Origin values identify which layer produced a flag's effective value in a
+// Result.
+const (
+	OriginDefault     = "default"
+	OriginConfigFile  = "config"
+	OriginEnvVar      = "env"
+	OriginCommandLine = "flag"
+)
+
+// ResultEntry describes the effective value Parse assigned to a single flag,
+// and which layer (origin) produced it. Origin is one of the Origin
+// constants, or the Name of a registered Source.
+type ResultEntry struct {
+	Value  string
+	Origin string
+}
+
+// Result maps a flag name to the ResultEntry describing its effective value,
+// as returned by ParseWithResult.
+type Result map[string]ResultEntry
+
+// Source is a layered provider of flag values, registered with WithSource.
+// Parse consults sources in descending priority order, interleaved with the
+// built-in environment variable and config file layers, stopping at the
+// first layer that has a value for a given flag.
+type Source interface {
+	// Name identifies this source, and is recorded as a ResultEntry's Origin
+	// when this source supplies a flag's effective value.
+	Name() string
+
+	// Get returns this source's value for the named flag, if it has one.
+	Get(name string) (value string, ok bool, err error)
+}
+
+// sourceLayer pairs a registered Source with the priority it was registered
+// at, so it can be sorted alongside the built-in layers.
+type sourceLayer struct {
+	source   Source
+	priority int
+}
 
 // Parse the flags in the flag set from the provided (presumably commandline)
-// args. Additional options may be provided to parse from a config file and/or
-// environment variables in that priority order.
+// args. Additional options may be provided to parse from a config file,
+// environment variables, and any extra sources registered via WithSource, in
+// descending priority order.
 func Parse(fs *flag.FlagSet, args []string, options ...Option) error {
+	_, err := ParseWithResult(fs, args, options...)
+	return err
+}
+
+// ParseWithResult behaves exactly like Parse, additionally returning a
+// Result describing the effective value of every flag in the set and which
+// layer produced it, so callers can log or debug where a value came from.
+func ParseWithResult(fs *flag.FlagSet, args []string, options ...Option) (Result, error) {
 	var c Context
 	for _, option := range options {
 		option(&c)
@@ -34,56 +179,169 @@ func Parse(fs *flag.FlagSet, args []string, options ...Option) error {
 		flag2env[f] = key
 	})
 
-	// First priority: commandline flags (explicit user preference).
-	if err := fs.Parse(args); err != nil {
-		return fmt.Errorf("error parsing commandline args: %w", err)
+	provided := map[string]bool{}
+	origin := map[string]string{}
+	markProvided := func(name, from string) {
+		if provided[name] {
+			return
+		}
+		provided[name] = true
+		origin[name] = from
 	}
 
-	provided := map[string]bool{}
+	// Highest priority: commandline flags (explicit user preference).
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("error parsing commandline args: %w", err)
+	}
 	fs.Visit(func(f *flag.Flag) {
-		provided[f.Name] = true
+		markProvided(f.Name, OriginCommandLine)
 	})
 
-	// Second priority: environment variables (session).
-	parseEnv := c.envVarPrefix != "" || c.envVarNoPrefix
-	if parseEnv {
-		var visitErr error
-		fs.VisitAll(func(f *flag.Flag) {
-			if visitErr != nil {
-				return
-			}
+	// The environment variable and config file layers, plus any extra
+	// sources registered via WithSource, run next in descending priority
+	// order; ties keep the built-in layer first.
+	type layer struct {
+		priority int
+		builtin  bool
+		run      func() error
+	}
 
-			if provided[f.Name] {
-				return
-			}
+	layers := []layer{
+		{
+			priority: PriorityEnvVar,
+			builtin:  true,
+			run: func() error {
+				if c.envVarPrefix == "" && !c.envVarNoPrefix {
+					return nil
+				}
+				var rerr error
+				fs.VisitAll(func(f *flag.Flag) {
+					if rerr != nil || provided[f.Name] {
+						return
+					}
+					key, ok := flag2env[f]
+					if !ok {
+						panic(fmt.Errorf("%s: invalid flag/env mapping", f.Name))
+					}
+					value := os.Getenv(key)
+					if value == "" {
+						return
+					}
+					for _, v := range maybeSplit(value, c.envVarSplit) {
+						if err := fs.Set(f.Name, v); err != nil {
+							rerr = fmt.Errorf("error setting flag %q from env var %q: %w", f.Name, key, err)
+							return
+						}
+					}
+					markProvided(f.Name, OriginEnvVar)
+				})
+				return rerr
+			},
+		},
+		{
+			priority: PriorityConfigFile,
+			builtin:  true,
+			run: func() error {
+				return parseConfigFileLayer(fs, &c, env2flag, provided, origin, markProvided)
+			},
+		},
+	}
+	for _, sl := range c.sources {
+		sl := sl
+		layers = append(layers, layer{
+			priority: sl.priority,
+			run: func() error {
+				var rerr error
+				fs.VisitAll(func(f *flag.Flag) {
+					if rerr != nil || provided[f.Name] {
+						return
+					}
+					value, ok, err := sl.source.Get(f.Name)
+					if err != nil {
+						rerr = fmt.Errorf("error getting flag %q from source %q: %w", f.Name, sl.source.Name(), err)
+						return
+					}
+					if !ok {
+						return
+					}
+					if err := fs.Set(f.Name, value); err != nil {
+						rerr = fmt.Errorf("error setting flag %q from source %q: %w", f.Name, sl.source.Name(), err)
+						return
+					}
+					markProvided(f.Name, sl.source.Name())
+				})
+				return rerr
+			},
+		})
+	}
 
-			key, ok := flag2env[f]
-			if !ok {
-				panic(fmt.Errorf("%s: invalid flag/env mapping", f.Name))
-			}
+	sort.SliceStable(layers, func(i, j int) bool {
+		if layers[i].priority != layers[j].priority {
+			return layers[i].priority > layers[j].priority
+		}
+		return layers[i].builtin && !layers[j].builtin
+	})
 
-			value := os.Getenv(key)
-			if value == "" {
-				return
-			}
+	// A layer failure is recorded but doesn't short-circuit validation:
+	// WithValidate and WithRequired run regardless, so a caller combining
+	// WithStrictConfig with WithRequired sees every problem Parse found, in
+	// one aggregated error, not just whichever layer happened to fail
+	// first.
+	var errs []error
+	for _, l := range layers {
+		if err := l.run(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+	}
 
-			for _, v := range maybeSplit(value, c.envVarSplit) {
-				if err := fs.Set(f.Name, v); err != nil {
-					visitErr = fmt.Errorf("error setting flag %q from env var %q: %w", f.Name, key, err)
-					return
+	if len(c.validators) > 0 {
+		fs.VisitAll(func(f *flag.Flag) {
+			source, ok := origin[f.Name]
+			if !ok {
+				source = OriginDefault
+			}
+			for _, validate := range c.validators {
+				if err := validate(f, source); err != nil {
+					errs = append(errs, err)
 				}
 			}
 		})
-		if visitErr != nil {
-			return fmt.Errorf("error parsing env vars: %w", visitErr)
-		}
 	}
 
-	fs.Visit(func(f *flag.Flag) {
-		provided[f.Name] = true
+	switch {
+	case len(errs) == 0:
+		// fall through to building the result
+	case len(c.validators) > 0:
+		// WithValidate/WithRequired promise *ParseError aggregation, so
+		// always return that type when a validator is registered, even if
+		// only one error -- from a layer or a validator -- was collected.
+		return nil, &ParseError{Errs: errs}
+	case len(errs) == 1:
+		return nil, errs[0]
+	default:
+		return nil, &ParseError{Errs: errs}
+	}
+
+	result := make(Result)
+	fs.VisitAll(func(f *flag.Flag) {
+		from, ok := origin[f.Name]
+		if !ok {
+			from = OriginDefault
+		}
+		result[f.Name] = ResultEntry{
+			Value:  f.Value.String(),
+			Origin: from,
+		}
 	})
 
-	// Third priority: config file (host).
+	return result, nil
+}
+
+// parseConfigFileLayer implements the config file layer of Parse: it
+// resolves the configured config file, if any, and feeds it through the
+// configured ConfigFileParser.
+func parseConfigFileLayer(fs *flag.FlagSet, c *Context, env2flag map[string]*flag.Flag, provided map[string]bool, origin map[string]string, markProvided func(name, from string)) error {
 	var configFile string
 	if c.configFileVia != nil {
 		configFile = *c.configFileVia
@@ -95,177 +353,103 @@ func Parse(fs *flag.FlagSet, args []string, options ...Option) error {
 		}
 	}
 
-	if c.configFileLookup == nil {
-		c.configFileLookup = func(fs *flag.FlagSet, name string) *flag.Flag {
-			return fs.Lookup(name)
-		}
-	}
-
 	var (
 		haveConfigFile  = configFile != ""
 		haveParser      = c.configFileParser != nil
 		parseConfigFile = haveConfigFile && haveParser
 	)
-	if parseConfigFile {
-		f, err := os.Open(configFile)
-		switch {
-		case err == nil:
-			defer f.Close()
-			if err := c.configFileParser(f, func(name, value string) error {
-				if provided[name] {
-					return nil
-				}
+	if !parseConfigFile {
+		return nil
+	}
 
-				var (
-					f1 = fs.Lookup(name)
-					f2 = env2flag[name]
-					f  *flag.Flag
-				)
-				switch {
-				case f1 == nil && f2 == nil && c.ignoreUndefined:
-					return nil
-				case f1 == nil && f2 == nil && !c.ignoreUndefined:
-					return fmt.Errorf("config file flag %q not defined in flag set", name)
-				case f1 != nil && f2 == nil:
-					f = f1
-				case f1 == nil && f2 != nil:
-					f = f2
-				case f1 != nil && f2 != nil && f1 == f2:
-					f = f1
-				case f1 != nil && f2 != nil && f1 != f2:
-					return fmt.Errorf("config file flag %q ambiguous: matches %s and %s", name, f1.Name, f2.Name)
+	f, err := os.Open(configFile)
+	switch {
+	case err == nil:
+		defer f.Close()
+
+		resolved := filepath.Clean(configFile)
+		ps := &ParseState{
+			Dir:          filepath.Dir(resolved),
+			IncludeDirs:  c.configFileIncludeDirs,
+			stack:        []string{resolved},
+			touchedFiles: map[string]bool{resolved: true},
+			maxDepth:     c.maxIncludeDepth,
+		}
+
+		var strictErrs []error
+
+		// blockedByOtherLayers is a snapshot of provided taken before this
+		// layer runs, so it reflects only what the commandline, env, and any
+		// higher-priority Source already set. The config-file layer checks
+		// against this frozen snapshot, not the live provided map, so that
+		// repeated calls to set from this layer -- one config file's own
+		// array leaf producing several values for a repeatable flag, or a
+		// value that flows in via an include -- aren't mistaken for a
+		// conflict with an earlier layer and silently dropped.
+		blockedByOtherLayers := make(map[string]bool, len(provided))
+		for name, ok := range provided {
+			blockedByOtherLayers[name] = ok
+		}
+
+		err = c.configFileParser(f, func(name, value string) error {
+			if blockedByOtherLayers[name] {
+				if c.strictConfig && origin[name] == OriginCommandLine {
+					strictErrs = append(strictErrs, fmt.Errorf("config file flag %q conflicts with a value already set on the commandline", name))
 				}
+				return nil
+			}
 
-				if provided[f.Name] {
+			var (
+				f1 = fs.Lookup(name)
+				f2 = env2flag[name]
+				f  *flag.Flag
+			)
+			switch {
+			case f1 == nil && f2 == nil && c.ignoreUndefinedConfigFlags:
+				return nil
+			case f1 == nil && f2 == nil && !c.ignoreUndefinedConfigFlags:
+				if c.strictConfig {
+					strictErrs = append(strictErrs, fmt.Errorf("config file flag %q not defined in flag set", name))
 					return nil
 				}
+				return fmt.Errorf("config file flag %q not defined in flag set", name)
+			case f1 != nil && f2 == nil:
+				f = f1
+			case f1 == nil && f2 != nil:
+				f = f2
+			case f1 != nil && f2 != nil && f1 == f2:
+				f = f1
+			case f1 != nil && f2 != nil && f1 != f2:
+				return fmt.Errorf("config file flag %q ambiguous: matches %s and %s", name, f1.Name, f2.Name)
+			}
 
-				if err := fs.Set(f.Name, value); err != nil {
-					return fmt.Errorf("error setting flag %q from config file: %w", name, err)
+			if blockedByOtherLayers[f.Name] {
+				if c.strictConfig && origin[f.Name] == OriginCommandLine {
+					strictErrs = append(strictErrs, fmt.Errorf("config file flag %q conflicts with a value already set on the commandline", name))
 				}
-
 				return nil
-			}); err != nil {
-				return err
 			}
 
-		case os.IsNotExist(err) && c.allowMissingConfigFile:
-			// no problem
+			if err := fs.Set(f.Name, value); err != nil {
+				return fmt.Errorf("error setting flag %q from config file: %w", name, err)
+			}
 
-		default:
+			markProvided(f.Name, OriginConfigFile)
+			return nil
+		}, ps)
+		if err != nil {
 			return err
 		}
-	}
-
-	fs.Visit(func(f *flag.Flag) {
-		provided[f.Name] = true
-	})
-
-	return nil
-}
-
-// Context contains private fields used during parsing.
-type Context struct {
-	configFileVia          *string
-	configFileFlagName     string
-	configFileParser       ConfigFileParser
-	configFileLookup       ConfigFileLookup
-	allowMissingConfigFile bool
-	envVarPrefix           string
-	envVarNoPrefix         bool
-	envVarSplit            string
-	ignoreUndefined        bool
-}
-
-// Option controls some aspect of Parse behavior.
-type Option func(*Context)
-
-// WithConfigFile tells Parse to read the provided filename as a config file.
-// Requires WithConfigFileParser, and overrides WithConfigFileFlag.
-// Because config files should generally be user-specifiable, this option
-// should be rarely used. Prefer WithConfigFileFlag.
-func WithConfigFile(filename string) Option {
-	return WithConfigFileVia(&filename)
-}
-
-// WithConfigFileVia tells Parse to read the provided filename as a config file.
-// Requires WithConfigFileParser, and overrides WithConfigFileFlag.
-// This is useful for sharing a single root level flag for config files among
-// multiple ffcli subcommands.
-func WithConfigFileVia(filename *string) Option {
-	return func(c *Context) {
-		c.configFileVia = filename
-	}
-}
-
-// WithConfigFileFlag tells Parse to treat the flag with the given name as a
-// config file. Requires WithConfigFileParser, and is overridden by
-// WithConfigFile.
-//
-// To specify a default config file, provide it as the default value of the
-// corresponding flag -- and consider also using the WithAllowMissingConfigFile
-// option.
-func WithConfigFileFlag(flagname string) Option {
-	return func(c *Context) {
-		c.configFileFlagName = flagname
-	}
-}
-
-// WithConfigFileParser tells Parse how to interpret the config file provided
-// via WithConfigFile or WithConfigFileFlag.
-func WithConfigFileParser(p ConfigFileParser) Option {
-	return func(c *Context) {
-		c.configFileParser = p
-	}
-}
-
-// WithAllowMissingConfigFile tells Parse to permit the case where a config file
-// is specified but doesn't exist. By default, missing config files result in an
-// error.
-func WithAllowMissingConfigFile(allow bool) Option {
-	return func(c *Context) {
-		c.allowMissingConfigFile = allow
-	}
-}
-
-// WithEnvVarPrefix tells Parse to try to set flags from environment variables
-// with the given prefix. Flag names are matched to environment variables with
-// the given prefix, followed by an underscore, followed by the capitalized flag
-// names, with separator characters like periods or hyphens replaced with
-// underscores. By default, flags are not set from environment variables at all.
-func WithEnvVarPrefix(prefix string) Option {
-	return func(c *Context) {
-		c.envVarPrefix = prefix
-	}
-}
-
-// WithEnvVarNoPrefix tells Parse to try to set flags from environment variables
-// without any specific prefix. Flag names are matched to environment variables
-// by capitalizing the flag name, and replacing separator characters like
-// periods or hyphens with underscores. By default, flags are not set from
-// environment variables at all.
-func WithEnvVarNoPrefix() Option {
-	return func(c *Context) {
-		c.envVarNoPrefix = true
-	}
-}
+		if len(strictErrs) > 0 {
+			return &ParseError{Errs: strictErrs}
+		}
+		return nil
 
-// WithEnvVarSplit tells Parse to split environment variables on the given
-// delimiter, and to make a call to Set on the corresponding flag with each
-// split token.
-func WithEnvVarSplit(delimiter string) Option {
-	return func(c *Context) {
-		c.envVarSplit = delimiter
-	}
-}
+	case os.IsNotExist(err) && c.allowMissingConfigFile:
+		return nil
 
-// WithIgnoreUndefined tells Parse to ignore undefined flags that it encounters
-// in config files. By default, if Parse encounters an undefined flag in a
-// config file, it will return an error. Note that this setting does not apply
-// to undefined flags passed as arguments.
-func WithIgnoreUndefined(ignore bool) Option {
-	return func(c *Context) {
-		c.ignoreUndefined = ignore
+	default:
+		return err
 	}
 }
 
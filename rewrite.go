@@ -0,0 +1,130 @@
+package ff
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RewriteArgs reads the config file named by WithConfigFile, WithConfigFileVia,
+// or WithConfigFileFlag -- interpreted with WithConfigFileParser, exactly as
+// Parse would -- and returns a new args slice with the config file's values
+// spliced in as "--name=value" flags ahead of the original args. This lets a
+// downstream CLI framework (cobra, urfave/cli, or the standard flag package)
+// see config file values as if the user had typed them, adopting ff's config
+// file support without replacing fs.Parse. A "--" separator in args, and
+// everything after it, passes through untouched, since RewriteArgs only ever
+// prepends.
+//
+// fs is not parsed or mutated; it's consulted only to validate flag names
+// found in the config file and, via WithConfigFileFlag, to fall back to a
+// flag's default when args doesn't name the config file explicitly. Flags in
+// the config file that aren't defined in fs are dropped when
+// WithIgnoreUndefinedConfigFlags is set, and are otherwise a parse error.
+func RewriteArgs(fs *flag.FlagSet, args []string, options ...Option) ([]string, error) {
+	var c Context
+	for _, option := range options {
+		option(&c)
+	}
+
+	var configFile string
+	if c.configFileVia != nil {
+		configFile = *c.configFileVia
+	}
+
+	if configFile == "" && c.configFileFlagName != "" {
+		if v, ok := scanFlagValue(args, c.configFileFlagName); ok {
+			configFile = v
+		} else if f := fs.Lookup(c.configFileFlagName); f != nil {
+			configFile = f.Value.String()
+		}
+	}
+
+	var (
+		haveConfigFile  = configFile != ""
+		haveParser      = c.configFileParser != nil
+		parseConfigFile = haveConfigFile && haveParser
+	)
+	if !parseConfigFile {
+		return args, nil
+	}
+
+	f, err := os.Open(configFile)
+	switch {
+	case err == nil:
+		defer f.Close()
+
+	case os.IsNotExist(err) && c.allowMissingConfigFile:
+		return args, nil
+
+	default:
+		return nil, err
+	}
+
+	resolved := filepath.Clean(configFile)
+	ps := &ParseState{
+		Dir:          filepath.Dir(resolved),
+		IncludeDirs:  c.configFileIncludeDirs,
+		stack:        []string{resolved},
+		touchedFiles: map[string]bool{resolved: true},
+		maxDepth:     c.maxIncludeDepth,
+	}
+
+	var rewritten []string
+	err = c.configFileParser(f, func(name, value string) error {
+		if fs.Lookup(name) == nil {
+			if c.ignoreUndefinedConfigFlags {
+				return nil
+			}
+			return fmt.Errorf("config file flag %q not defined in flag set", name)
+		}
+		rewritten = append(rewritten, "--"+name+"="+value)
+		return nil
+	}, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(rewritten, args...), nil
+}
+
+// scanFlagValue looks for -name/--name, either as "-name=value" or as
+// "-name" "value", in args, without otherwise interpreting args as flag.Parse
+// would -- RewriteArgs runs before any flag set has parsed these args. It
+// stops at a "--" separator, since args after it are never flags.
+func scanFlagValue(args []string, name string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return "", false
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed == "" {
+			continue
+		}
+
+		if eq := strings.IndexByte(trimmed, '='); eq >= 0 {
+			if trimmed[:eq] == name {
+				return trimmed[eq+1:], true
+			}
+			continue
+		}
+
+		if trimmed != name {
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return "", false
+		}
+		return args[i+1], true
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+package ff
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRewriteArgs_DoubleDashPassthrough(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"a":"from-config"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("a", "", "")
+
+	args := []string{"serve", "--", "--a=should-not-be-touched"}
+	got, err := RewriteArgs(fs, args,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+	)
+	if err != nil {
+		t.Fatalf("RewriteArgs: %v", err)
+	}
+
+	want := []string{"--a=from-config", "serve", "--", "--a=should-not-be-touched"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RewriteArgs = %v, want %v", got, want)
+	}
+}
+
+func TestRewriteArgs_IgnoreUndefinedConfigFlags(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"a":"from-config","unknown":"x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("a", "", "")
+
+	args := []string{"serve"}
+
+	if _, err := RewriteArgs(fs, args,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+	); err == nil {
+		t.Fatal("RewriteArgs: want error for undefined config flag, got nil")
+	}
+
+	got, err := RewriteArgs(fs, args,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+		WithIgnoreUndefinedConfigFlags(),
+	)
+	if err != nil {
+		t.Fatalf("RewriteArgs: %v", err)
+	}
+
+	want := []string{"--a=from-config", "serve"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RewriteArgs = %v, want %v", got, want)
+	}
+}
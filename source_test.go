@@ -0,0 +1,80 @@
+package ff
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mapSource is a minimal Source backed by a map, for exercising WithSource's
+// priority ordering against the built-in env var and config file layers.
+type mapSource struct {
+	name   string
+	values map[string]string
+}
+
+func (s *mapSource) Name() string { return s.name }
+
+func (s *mapSource) Get(name string) (string, bool, error) {
+	v, ok := s.values[name]
+	return v, ok, nil
+}
+
+func TestParse_SourcePriorityBetweenEnvAndConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	body := `{"a":"from-config","b":"from-config","c":"from-config"}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_A", "from-env")
+
+	src := &mapSource{
+		name: "custom",
+		values: map[string]string{
+			"a": "from-source",
+			"b": "from-source",
+		},
+	}
+
+	var a, b, c string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&a, "a", "", "")
+	fs.StringVar(&b, "b", "", "")
+	fs.StringVar(&c, "c", "", "")
+
+	// src's priority (30) sits strictly between PriorityConfigFile (10) and
+	// PriorityEnvVar (50), so it should win over the config file but lose to
+	// the environment variable.
+	result, err := ParseWithResult(fs, nil,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+		WithEnvVarPrefix("TEST"),
+		WithSource(src, 30),
+	)
+	if err != nil {
+		t.Fatalf("ParseWithResult: %v", err)
+	}
+
+	if a != "from-env" {
+		t.Errorf("a = %q, want %q", a, "from-env")
+	}
+	if b != "from-source" {
+		t.Errorf("b = %q, want %q", b, "from-source")
+	}
+	if c != "from-config" {
+		t.Errorf("c = %q, want %q", c, "from-config")
+	}
+
+	if got := result["a"].Origin; got != OriginEnvVar {
+		t.Errorf("a origin = %q, want %q", got, OriginEnvVar)
+	}
+	if got := result["b"].Origin; got != "custom" {
+		t.Errorf("b origin = %q, want %q", got, "custom")
+	}
+	if got := result["c"].Origin; got != OriginConfigFile {
+		t.Errorf("c origin = %q, want %q", got, OriginConfigFile)
+	}
+}
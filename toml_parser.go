@@ -0,0 +1,31 @@
+package ff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLParser is a ConfigFileParser which parses a TOML document, flattening
+// nested tables into dotted flag names -- e.g. "[server]\nlisten-addr =
+// \":8080\"" becomes the flag name "server.listen-addr" -- and calling set
+// once per leaf. Arrays call set once per element, so a TOML array can
+// populate a repeatable flag. A top-level "include" or "@include" key,
+// holding a string or list of strings, pulls in sibling files after this
+// document's own keys are applied, so this document's values win over an
+// included file's; see ParseState.Include for cycle detection and search
+// path rules.
+func TOMLParser(r io.Reader, set func(name, value string) error, ps *ParseState) error {
+	var doc map[string]interface{}
+	if _, err := toml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("error parsing TOML config: %w", err)
+	}
+
+	includes, err := popIncludes(doc)
+	if err != nil {
+		return fmt.Errorf("error parsing TOML config: %w", err)
+	}
+
+	return applyWithIncludes(doc, includes, ps, TOMLParser, set)
+}
@@ -0,0 +1,58 @@
+package ff
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse_ValidatorsRunAfterStrictConfigFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"undefined-flag":"x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var a, b string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&a, "a", "", "")
+	fs.StringVar(&b, "b", "", "")
+
+	err := Parse(fs, nil,
+		WithConfigFile(path),
+		WithConfigFileParser(JSONParser),
+		WithStrictConfig(true),
+		WithRequired("b"),
+	)
+	if err == nil {
+		t.Fatal("Parse: want error, got nil")
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Parse: want *ParseError, got %T: %v", err, err)
+	}
+	if len(perr.Errs) != 2 {
+		t.Fatalf("ParseError.Errs = %v, want 2 errors (one for the undefined config flag, one for the missing required flag)", perr.Errs)
+	}
+}
+
+func TestParse_SingleRequiredErrorIsParseError(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("b", "", "")
+
+	err := Parse(fs, nil, WithRequired("b"))
+	if err == nil {
+		t.Fatal("Parse: want error, got nil")
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Parse: want *ParseError (WithRequired documents *ParseError aggregation), got %T: %v", err, err)
+	}
+	if len(perr.Errs) != 1 {
+		t.Fatalf("ParseError.Errs = %v, want 1 error", perr.Errs)
+	}
+}
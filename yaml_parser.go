@@ -0,0 +1,95 @@
+package ff
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLParser is a ConfigFileParser which parses a YAML document, flattening
+// nested mappings into dotted flag names -- e.g. "server:\n  listen-addr:
+// :8080" becomes the flag name "server.listen-addr" -- and calling set once
+// per leaf. Sequences call set once per element, so a YAML list can populate
+// a repeatable flag. A top-level "include" or "@include" key, holding a
+// string or list of strings, pulls in sibling files after this document's
+// own keys are applied, so this document's values win over an included
+// file's; see ParseState.Include for cycle detection and search path rules.
+func YAMLParser(r io.Reader, set func(name, value string) error, ps *ParseState) error {
+	var raw interface{}
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return fmt.Errorf("error parsing YAML config: %w", err)
+	}
+
+	doc, err := toStringMap(raw)
+	if err != nil {
+		return fmt.Errorf("error parsing YAML config: %w", err)
+	}
+
+	includes, err := popIncludes(doc)
+	if err != nil {
+		return fmt.Errorf("error parsing YAML config: %w", err)
+	}
+
+	return applyWithIncludes(doc, includes, ps, YAMLParser, set)
+}
+
+// toStringMap converts the map[string]interface{} and map[interface{}]interface{}
+// values that yaml.v3 produces for mappings into the map[string]interface{}
+// tree that flattenConfig expects.
+func toStringMap(v interface{}) (map[string]interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			c, err := normalizeYAML(val)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("non-string key %v", k)
+			}
+			c, err := normalizeYAML(val)
+			if err != nil {
+				return nil, err
+			}
+			out[ks] = c
+		}
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("top-level YAML document must be a mapping")
+	}
+}
+
+func normalizeYAML(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return toStringMap(v)
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			c, err := normalizeYAML(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+
+	default:
+		return v, nil
+	}
+}